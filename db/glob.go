@@ -0,0 +1,105 @@
+package db
+
+// globMatch reports whether member matches pattern, which may use the
+// glob wildcards *, ? and [...] as accepted by Redis's SCAN family of
+// commands. A nil or empty pattern matches everything.
+func globMatch(pattern, member []byte) (bool, error) {
+	if len(pattern) == 0 {
+		return true, nil
+	}
+	return globMatchAt(pattern, member), nil
+}
+
+func globMatchAt(pattern, s []byte) bool {
+	for len(pattern) > 0 {
+		switch pattern[0] {
+		case '*':
+			for len(pattern) > 1 && pattern[1] == '*' {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 1 {
+				return true
+			}
+			for i := 0; i <= len(s); i++ {
+				if globMatchAt(pattern[1:], s[i:]) {
+					return true
+				}
+			}
+			return false
+		case '?':
+			if len(s) == 0 {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		case '[':
+			if len(s) == 0 {
+				return false
+			}
+			end := globClassEnd(pattern)
+			if end < 0 {
+				if s[0] != '[' {
+					return false
+				}
+				s = s[1:]
+				pattern = pattern[1:]
+				continue
+			}
+			if !globMatchClass(pattern[1:end], s[0]) {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[end+1:]
+		case '\\':
+			if len(pattern) > 1 {
+				pattern = pattern[1:]
+			}
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		default:
+			if len(s) == 0 || s[0] != pattern[0] {
+				return false
+			}
+			s = s[1:]
+			pattern = pattern[1:]
+		}
+	}
+	return len(s) == 0
+}
+
+func globClassEnd(pattern []byte) int {
+	for i := 1; i < len(pattern); i++ {
+		if pattern[i] == ']' {
+			return i
+		}
+	}
+	return -1
+}
+
+func globMatchClass(class []byte, c byte) bool {
+	negate := false
+	if len(class) > 0 && (class[0] == '^' || class[0] == '!') {
+		negate = true
+		class = class[1:]
+	}
+	matched := false
+	for i := 0; i < len(class); i++ {
+		if i+2 < len(class) && class[i+1] == '-' {
+			if class[i] <= c && c <= class[i+2] {
+				matched = true
+			}
+			i += 2
+			continue
+		}
+		if class[i] == c {
+			matched = true
+		}
+	}
+	if negate {
+		return !matched
+	}
+	return matched
+}
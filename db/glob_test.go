@@ -0,0 +1,37 @@
+package db
+
+import "testing"
+
+func TestGlobMatch(t *testing.T) {
+	cases := []struct {
+		pattern string
+		member  string
+		want    bool
+	}{
+		{"", "anything", true},
+		{"*", "", true},
+		{"*", "anything", true},
+		{"foo*", "foobar", true},
+		{"foo*", "barfoo", false},
+		{"f?o", "foo", true},
+		{"f?o", "fo", false},
+		{"[abc]ar", "bar", true},
+		{"[abc]ar", "dar", false},
+		{"[^abc]ar", "dar", true},
+		{"[a-c]ar", "bar", true},
+		{"[a-c]ar", "zar", false},
+		{"h[ae]llo", "hello", true},
+		{"h[ae]llo", "hillo", false},
+		{`\*foo`, "*foo", true},
+		{`\*foo`, "foo", false},
+	}
+	for _, c := range cases {
+		got, err := globMatch([]byte(c.pattern), []byte(c.member))
+		if err != nil {
+			t.Fatalf("globMatch(%q, %q) returned error: %v", c.pattern, c.member, err)
+		}
+		if got != c.want {
+			t.Errorf("globMatch(%q, %q) = %v, want %v", c.pattern, c.member, got, c.want)
+		}
+	}
+}
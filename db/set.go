@@ -3,8 +3,11 @@ package db
 import (
 	"bytes"
 	"encoding/binary"
+	"math/rand"
+	"sort"
+	"strconv"
 
-	"github.com/pingcap/tidb/kv"
+	"github.com/RoaringBitmap/roaring"
 )
 
 // SetNilValue is the value set to a tikv key for tikv do not support a real empty value
@@ -14,6 +17,12 @@ var SetNilValue = []byte{0}
 type SetMeta struct {
 	Object
 	Len int64
+	// MigratedItemKeys is true once migrateLegacyItems is known to have
+	// nothing left to do for this set: either it has never used the
+	// pre-length-prefix item-key layout, or a prior call already rewrote
+	// every legacy key it had. It lets ensureHT skip the range scan in
+	// steady state instead of repeating an empty scan on every call.
+	MigratedItemKeys bool
 }
 
 // Set implements the set data structure
@@ -60,8 +69,7 @@ type SetIter struct {
 func (set *Set) Iter() (*SetIter, error) {
 	var siter SetIter
 	dkey := DataKey(set.txn.db, set.meta.ID)
-	prefix := append(dkey, ':')
-	endPrefix := kv.Key(prefix).PrefixNext()
+	prefix, endPrefix := setItemRange(dkey)
 	iter, err := set.txn.t.Iter(prefix, endPrefix)
 	if err != nil {
 		return nil, err
@@ -98,17 +106,20 @@ func newSet(txn *Transaction, key []byte) *Set {
 				UpdatedAt: now,
 				ExpireAt:  0,
 				Type:      ObjectSet,
-				Encoding:  ObjectEncodingHT,
+				Encoding:  ObjectEncodingIntSet,
 			},
-			Len: 0,
+			Len:              0,
+			MigratedItemKeys: true,
 		},
 	}
 }
 
 // DecodeSetMeta decode meta data into meta field
 func DecodeSetMeta(b []byte) (*SetMeta, error) {
-	if b != nil && len(b[ObjectEncodingLength:]) != 8 {
-		return nil, ErrInvalidLength
+	if b != nil {
+		if tail := len(b[ObjectEncodingLength:]); tail != 8 && tail != 9 {
+			return nil, ErrInvalidLength
+		}
 	}
 	obj, err := DecodeObject(b)
 	if err != nil {
@@ -117,25 +128,27 @@ func DecodeSetMeta(b []byte) (*SetMeta, error) {
 	smeta := &SetMeta{Object: *obj}
 	m := b[ObjectEncodingLength:]
 	smeta.Len = int64(binary.BigEndian.Uint64(m[:8]))
+	// Metas written before MigratedItemKeys existed are 8 bytes long and
+	// default to false, so the first ensureHT call after an upgrade
+	// still runs the legacy-key scan once, same as before this field was
+	// added.
+	if len(m) == 9 {
+		smeta.MigratedItemKeys = m[8] != 0
+	}
 	return smeta, nil
 }
 
 //encodeSetMeta encodes meta data into byte slice
 func encodeSetMeta(meta *SetMeta) []byte {
 	b := EncodeObject(&meta.Object)
-	m := make([]byte, 8)
+	m := make([]byte, 9)
 	binary.BigEndian.PutUint64(m[:8], uint64(meta.Len))
+	if meta.MigratedItemKeys {
+		m[8] = 1
+	}
 	return append(b, m...)
 }
 
-func setItemKey(key []byte, member []byte) []byte {
-	var ikeys []byte
-	ikeys = append(ikeys, key...)
-	ikeys = append(ikeys, ':')
-	ikeys = append(ikeys, member...)
-	return ikeys
-}
-
 func (set *Set) updateMeta() error {
 	meta := encodeSetMeta(set.meta)
 	err := set.txn.t.Set(MetaKey(set.txn.db, set.key), meta)
@@ -151,13 +164,37 @@ func (set *Set) updateMeta() error {
 
 // SAdd adds the specified members to the set stored at key
 func (set *Set) SAdd(members ...[]byte) (int64, error) {
+	if set.meta.Encoding == ObjectEncodingIntSet {
+		added, ok, err := set.sAddIntSet(members)
+		if err != nil {
+			return 0, err
+		}
+		if ok {
+			return added, nil
+		}
+		// sAddIntSet promoted the set to ObjectEncodingHT without
+		// consuming members; fall through to the regular path.
+	}
+	return set.sAddHT(members)
+}
+
+// sAddHT adds the specified members one TiKV key per member. This is the
+// layout used by ObjectEncodingHT-encoded sets.
+func (set *Set) sAddHT(members [][]byte) (int64, error) {
+	if err := set.ensureHT(); err != nil {
+		return 0, err
+	}
 	// Namespace:DBID:D:ObjectID
 	dkey := DataKey(set.txn.db, set.meta.ID)
 	// Remove the duplicate
 	ms := RemoveRepByMap(members)
 	ikeys := make([][]byte, len(ms))
 	for i := range ms {
-		ikeys[i] = setItemKey(dkey, ms[i])
+		ikey, err := setItemKey(dkey, ms[i])
+		if err != nil {
+			return 0, err
+		}
+		ikeys[i] = ikey
 	}
 	// {Namespace}:{DBID}:{D}:{ObjectID}:{ms[i]}
 	values, err := BatchGetValues(set.txn, ikeys)
@@ -207,9 +244,18 @@ func (set *Set) SMembers() ([][]byte, error) {
 	if !set.Exists() {
 		return nil, nil
 	}
+	if set.meta.Encoding == ObjectEncodingIntSet {
+		bm, err := set.loadIntSet()
+		if err != nil {
+			return nil, err
+		}
+		return bitmapMembers(bm), nil
+	}
+	if err := set.ensureHT(); err != nil {
+		return nil, err
+	}
 	dkey := DataKey(set.txn.db, set.meta.ID)
-	prefix := append(dkey, ':')
-	endPrefix := kv.Key(prefix).PrefixNext()
+	prefix, endPrefix := setItemRange(dkey)
 	count := set.meta.Len
 	members := make([][]byte, 0, count)
 	iter, err := set.txn.t.Iter(prefix, endPrefix)
@@ -239,8 +285,25 @@ func (set *Set) SIsmember(member []byte) (int64, error) {
 	if !set.Exists() {
 		return 0, nil
 	}
+	if set.meta.Encoding == ObjectEncodingIntSet {
+		bm, err := set.loadIntSet()
+		if err != nil {
+			return 0, err
+		}
+		v, ok := parseSetMemberInt(member)
+		if !ok || !bm.Contains(v) {
+			return 0, nil
+		}
+		return 1, nil
+	}
+	if err := set.ensureHT(); err != nil {
+		return 0, err
+	}
 	dkey := DataKey(set.txn.db, set.meta.ID)
-	ikey := setItemKey(dkey, member)
+	ikey, err := setItemKey(dkey, member)
+	if err != nil {
+		return 0, err
+	}
 
 	value, err := set.txn.t.Get(ikey)
 	if err != nil {
@@ -260,9 +323,11 @@ func (set *Set) SPop(count int64) ([][]byte, error) {
 	if !set.Exists() || set.meta.Len == 0 {
 		return make([][]byte, 0), nil
 	}
+	if err := set.ensureHT(); err != nil {
+		return nil, err
+	}
 	dkey := DataKey(set.txn.db, set.meta.ID)
-	prefix := append(dkey, ':')
-	endPrefix := kv.Key(prefix).PrefixNext()
+	prefix, endPrefix := setItemRange(dkey)
 	iter, err := set.txn.t.Iter(prefix, endPrefix)
 	if err != nil {
 		return nil, err
@@ -294,11 +359,18 @@ func (set *Set) SRem(members [][]byte) (int64, error) {
 	if !set.Exists() {
 		return 0, nil
 	}
+	if err := set.ensureHT(); err != nil {
+		return 0, err
+	}
 	dkey := DataKey(set.txn.db, set.meta.ID)
 	ms := RemoveRepByMap(members)
 	ikeys := make([][]byte, len(ms))
 	for i := range ms {
-		ikeys[i] = setItemKey(dkey, ms[i])
+		ikey, err := setItemKey(dkey, ms[i])
+		if err != nil {
+			return 0, err
+		}
+		ikeys[i] = ikey
 		value, err := set.txn.t.Get(ikeys[i])
 		if err != nil {
 			if IsErrNotFound(err) {
@@ -332,6 +404,9 @@ func (set *Set) SMove(destination []byte, member []byte) (int64, error) {
 	if res == 0 {
 		return 0, nil
 	}
+	if err := set.ensureHT(); err != nil {
+		return 0, err
+	}
 	destset, err := GetSet(set.txn, destination)
 	if err != nil {
 		return 0, nil
@@ -348,7 +423,10 @@ func (set *Set) SMove(destination []byte, member []byte) (int64, error) {
 		destset.meta.Len++
 	}
 	dkey := DataKey(set.txn.db, set.meta.ID)
-	ikey := setItemKey(dkey, member)
+	ikey, err := setItemKey(dkey, member)
+	if err != nil {
+		return 0, err
+	}
 	if err := set.txn.t.Delete([]byte(ikey)); err != nil {
 		return 0, err
 	}
@@ -358,3 +436,538 @@ func (set *Set) SMove(destination []byte, member []byte) (int64, error) {
 	}
 	return 1, nil
 }
+
+// clear removes every member of the set and resets its length and
+// encoding to the default, without touching the meta key's identity (so
+// destination keys keep their ID across repeated *Store calls).
+func (set *Set) clear() error {
+	if !set.Exists() {
+		return nil
+	}
+	dkey := DataKey(set.txn.db, set.meta.ID)
+	if set.meta.Encoding == ObjectEncodingIntSet {
+		if err := set.txn.t.Delete(dkey); err != nil && !IsErrNotFound(err) {
+			return err
+		}
+	} else {
+		if err := set.migrateLegacyItems(); err != nil {
+			return err
+		}
+		prefix, endPrefix := setItemRange(dkey)
+		iter, err := set.txn.t.Iter(prefix, endPrefix)
+		if err != nil {
+			return err
+		}
+		defer iter.Close()
+		for iter.Valid() && iter.Key().HasPrefix(prefix) {
+			if err := set.txn.t.Delete([]byte(iter.Key())); err != nil {
+				return err
+			}
+			if err := iter.Next(); err != nil {
+				return err
+			}
+		}
+	}
+	set.meta.Len = 0
+	set.meta.Encoding = ObjectEncodingIntSet
+	set.meta.MigratedItemKeys = true
+	return set.updateMeta()
+}
+
+// storeResult overwrites destination with members, reusing SAdd's batched
+// write path. GetSet already surfaces ErrTypeMismatch when destination
+// exists with a non-Set object type.
+func (set *Set) storeResult(destination []byte, members [][]byte) (int64, error) {
+	destset, err := GetSet(set.txn, destination)
+	if err != nil {
+		return 0, err
+	}
+	if err := destset.clear(); err != nil {
+		return 0, err
+	}
+	if len(members) == 0 {
+		return 0, nil
+	}
+	return destset.SAdd(members...)
+}
+
+// openSets resolves set for the receiver and every key, in receiver-first
+// order, for use by the multi-key algebraic operations below.
+func (set *Set) openSets(keys [][]byte) ([]*Set, error) {
+	sets := make([]*Set, 0, len(keys)+1)
+	sets = append(sets, set)
+	for _, key := range keys {
+		s, err := GetSet(set.txn, key)
+		if err != nil {
+			return nil, err
+		}
+		sets = append(sets, s)
+	}
+	return sets, nil
+}
+
+// SUnion returns the members of the set resulting from the union of the
+// set at key and all the given sets.
+func (set *Set) SUnion(keys ...[]byte) ([][]byte, error) {
+	sets, err := set.openSets(keys)
+	if err != nil {
+		return nil, err
+	}
+	if allIntSet(sets) {
+		union := roaring.New()
+		for _, s := range sets {
+			if !s.Exists() {
+				continue
+			}
+			bm, err := s.loadIntSet()
+			if err != nil {
+				return nil, err
+			}
+			union.Or(bm)
+		}
+		return bitmapMembers(union), nil
+	}
+	seen := map[string][]byte{}
+	for _, s := range sets {
+		if !s.Exists() {
+			continue
+		}
+		members, err := s.SMembers()
+		if err != nil {
+			return nil, err
+		}
+		for _, member := range members {
+			seen[string(member)] = member
+		}
+	}
+	members := make([][]byte, 0, len(seen))
+	for _, member := range seen {
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// SInter returns the members of the set resulting from the intersection
+// of the set at key and all the given sets. It iterates the smallest set
+// first and probes the rest one member at a time via probeMember, which
+// never promotes an IntSet-encoded other to ObjectEncodingHT just to
+// answer a membership check.
+func (set *Set) SInter(keys ...[]byte) ([][]byte, error) {
+	sets, err := set.openSets(keys)
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sets {
+		if !s.Exists() {
+			return [][]byte{}, nil
+		}
+	}
+	if allIntSet(sets) {
+		inter, err := sets[0].loadIntSet()
+		if err != nil {
+			return nil, err
+		}
+		inter = inter.Clone()
+		for _, s := range sets[1:] {
+			bm, err := s.loadIntSet()
+			if err != nil {
+				return nil, err
+			}
+			inter.And(bm)
+		}
+		return bitmapMembers(inter), nil
+	}
+
+	sort.Slice(sets, func(i, j int) bool { return sets[i].meta.Len < sets[j].meta.Len })
+	smallest, others := sets[0], sets[1:]
+
+	candidates, err := smallest.SMembers()
+	if err != nil {
+		return nil, err
+	}
+	result := make([][]byte, 0, len(candidates))
+	for _, member := range candidates {
+		inAll := true
+		for _, other := range others {
+			in, err := set.probeMember(other, member)
+			if err != nil {
+				return nil, err
+			}
+			if !in {
+				inAll = false
+				break
+			}
+		}
+		if inAll {
+			result = append(result, member)
+		}
+	}
+	return result, nil
+}
+
+// SDiff returns the members of the set resulting from the difference
+// between the set at key and all the given sets.
+func (set *Set) SDiff(keys ...[]byte) ([][]byte, error) {
+	if !set.Exists() {
+		return [][]byte{}, nil
+	}
+	others := make([]*Set, 0, len(keys))
+	for _, key := range keys {
+		s, err := GetSet(set.txn, key)
+		if err != nil {
+			return nil, err
+		}
+		others = append(others, s)
+	}
+	if allIntSet(append([]*Set{set}, others...)) {
+		diff, err := set.loadIntSet()
+		if err != nil {
+			return nil, err
+		}
+		diff = diff.Clone()
+		for _, other := range others {
+			if !other.Exists() {
+				continue
+			}
+			bm, err := other.loadIntSet()
+			if err != nil {
+				return nil, err
+			}
+			diff.AndNot(bm)
+		}
+		return bitmapMembers(diff), nil
+	}
+	members, err := set.SMembers()
+	if err != nil {
+		return nil, err
+	}
+	result := make([][]byte, 0, len(members))
+	for _, member := range members {
+		inAny := false
+		for _, other := range others {
+			if !other.Exists() {
+				continue
+			}
+			in, err := set.probeMember(other, member)
+			if err != nil {
+				return nil, err
+			}
+			if in {
+				inAny = true
+				break
+			}
+		}
+		if !inAny {
+			result = append(result, member)
+		}
+	}
+	return result, nil
+}
+
+// probeMember reports whether member is present in other. Unlike the
+// general per-member code paths, it never promotes an IntSet-encoded
+// other to ObjectEncodingHT: SInter/SDiff only need a yes/no answer for
+// each candidate member, so a roaring.Bitmap.Contains check is enough
+// and leaves other's encoding untouched.
+func (set *Set) probeMember(other *Set, member []byte) (bool, error) {
+	if other.meta.Encoding == ObjectEncodingIntSet {
+		v, ok := parseSetMemberInt(member)
+		if !ok {
+			return false, nil
+		}
+		bm, err := other.loadIntSet()
+		if err != nil {
+			return false, err
+		}
+		return bm.Contains(v), nil
+	}
+	if err := other.migrateLegacyItems(); err != nil {
+		return false, err
+	}
+	dkey := DataKey(set.txn.db, other.meta.ID)
+	ikey, err := setItemKey(dkey, member)
+	if err != nil {
+		return false, err
+	}
+	values, err := BatchGetValues(set.txn, [][]byte{ikey})
+	if err != nil {
+		return false, err
+	}
+	return values[0] != nil, nil
+}
+
+// SRandMember returns an array of random members of the set. A positive
+// count returns up to count distinct members (fewer if the set is
+// smaller); a negative count returns exactly |count| members, allowing
+// duplicates; count == 1 is the single-member form. Because the set is
+// laid out as sorted TiKV keys rather than a true random-access
+// structure, each sample seeks to a random point in the key range and
+// walks forward from there, so the resulting distribution is only
+// approximately uniform. Picking distinct members this way degrades
+// badly once count is a large fraction of the set -- the coupon
+// collector's problem means the last few unseen members take
+// disproportionately many retries to land on -- so that case instead
+// reads every member once and shuffles in memory. An IntSet-encoded set
+// is sampled straight off its Roaring bitmap (sRandMemberIntSet) rather
+// than promoted to ObjectEncodingHT just to answer a read.
+func (set *Set) SRandMember(count int64) ([][]byte, error) {
+	if !set.Exists() || set.meta.Len == 0 || count == 0 {
+		return [][]byte{}, nil
+	}
+	withRepetition := count < 0
+	if withRepetition {
+		count = -count
+	}
+	if !withRepetition && count > set.meta.Len {
+		count = set.meta.Len
+	}
+	if !withRepetition && count*2 > set.meta.Len {
+		members, err := set.SMembers()
+		if err != nil {
+			return nil, err
+		}
+		shuffleMembers(members)
+		return members[:count], nil
+	}
+	if set.meta.Encoding == ObjectEncodingIntSet {
+		return set.sRandMemberIntSet(count, withRepetition)
+	}
+	if err := set.ensureHT(); err != nil {
+		return nil, err
+	}
+
+	dkey := DataKey(set.txn.db, set.meta.ID)
+	prefix, endPrefix := setItemRange(dkey)
+
+	seen := map[string]struct{}{}
+	members := make([][]byte, 0, count)
+	for int64(len(members)) < count {
+		member, err := set.randomMember(prefix, endPrefix)
+		if err != nil {
+			return nil, err
+		}
+		if member == nil {
+			break
+		}
+		if !withRepetition {
+			if _, ok := seen[string(member)]; ok {
+				continue
+			}
+			seen[string(member)] = struct{}{}
+		}
+		members = append(members, member)
+	}
+	return members, nil
+}
+
+// sRandMemberIntSet implements the remaining SRandMember cases --
+// repetition sampling, and distinct sampling for a small fraction of the
+// set -- for an IntSet-encoded set without promoting it to
+// ObjectEncodingHT. bm.Select gives O(log n) random access by rank, so
+// each draw costs one Select instead of one TiKV seek.
+func (set *Set) sRandMemberIntSet(count int64, withRepetition bool) ([][]byte, error) {
+	bm, err := set.loadIntSet()
+	if err != nil {
+		return nil, err
+	}
+	card := int64(bm.GetCardinality())
+	if card == 0 {
+		return [][]byte{}, nil
+	}
+	seen := map[uint32]struct{}{}
+	members := make([][]byte, 0, count)
+	for int64(len(members)) < count {
+		v, err := bm.Select(uint32(rand.Int63n(card)))
+		if err != nil {
+			return nil, err
+		}
+		if !withRepetition {
+			if _, ok := seen[v]; ok {
+				continue
+			}
+			seen[v] = struct{}{}
+		}
+		members = append(members, []byte(strconv.FormatUint(uint64(v), 10)))
+	}
+	return members, nil
+}
+
+// randomMember seeks a random point within [prefix, endPrefix) and
+// returns the first member found at or after it, wrapping around to
+// prefix when the random seek lands past the end of the set.
+func (set *Set) randomMember(prefix, endPrefix []byte) ([]byte, error) {
+	seek := append(append([]byte{}, prefix...), randomBytes(8)...)
+	if bytes.Compare(seek, endPrefix) >= 0 {
+		seek = append([]byte{}, prefix...)
+	}
+	iter, err := set.txn.t.Iter(seek, endPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer iter.Close()
+	if iter.Valid() && iter.Key().HasPrefix(prefix) {
+		return append([]byte{}, iter.Key()[len(prefix):]...), nil
+	}
+
+	wrapped, err := set.txn.t.Iter(prefix, endPrefix)
+	if err != nil {
+		return nil, err
+	}
+	defer wrapped.Close()
+	if !wrapped.Valid() || !wrapped.Key().HasPrefix(prefix) {
+		return nil, nil
+	}
+	return append([]byte{}, wrapped.Key()[len(prefix):]...), nil
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
+
+// shuffleMembers randomises the order of members in place using the
+// Fisher-Yates shuffle.
+func shuffleMembers(members [][]byte) {
+	for i := len(members) - 1; i > 0; i-- {
+		j := rand.Intn(i + 1)
+		members[i], members[j] = members[j], members[i]
+	}
+}
+
+// SScan incrementally iterates the members of the set, resuming from
+// cursor. Each call examines at most count members and returns whichever
+// of those match the glob pattern match (a nil/empty pattern matches
+// everything), the same "count bounds work done, not results returned"
+// contract as Redis's SSCAN -- a selective pattern can legitimately come
+// back with zero members without having scanned the whole set. The
+// returned cursor is the last member examined during the scan, or an
+// empty slice once the whole set has been walked. An IntSet-encoded set
+// is walked straight off its Roaring bitmap (sScanIntSet) rather than
+// promoted to ObjectEncodingHT just to answer a read.
+func (set *Set) SScan(cursor []byte, match []byte, count int64) ([]byte, [][]byte, error) {
+	if !set.Exists() {
+		return []byte{}, nil, nil
+	}
+	if count <= 0 {
+		count = 10
+	}
+	if set.meta.Encoding == ObjectEncodingIntSet {
+		return set.sScanIntSet(cursor, match, count)
+	}
+	if err := set.ensureHT(); err != nil {
+		return nil, nil, err
+	}
+	dkey := DataKey(set.txn.db, set.meta.ID)
+	prefix, endPrefix := setItemRange(dkey)
+
+	start := prefix
+	if len(cursor) > 0 {
+		cursorKey, err := setItemKey(dkey, cursor)
+		if err != nil {
+			return nil, nil, err
+		}
+		start = cursorKey
+	}
+	iter, err := set.txn.t.Iter(start, endPrefix)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer iter.Close()
+
+	if len(cursor) > 0 && iter.Valid() && bytes.Equal([]byte(iter.Key()), start) {
+		if err := iter.Next(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	var next []byte
+	members := make([][]byte, 0, count)
+	var examined int64
+	for iter.Valid() && iter.Key().HasPrefix(prefix) && examined < count {
+		member := iter.Key()[len(prefix):]
+		matched, err := globMatch(match, member)
+		if err != nil {
+			return nil, nil, err
+		}
+		if matched {
+			members = append(members, append([]byte{}, member...))
+		}
+		next = append([]byte{}, member...)
+		examined++
+		if err := iter.Next(); err != nil {
+			return nil, nil, err
+		}
+	}
+	if !iter.Valid() || !iter.Key().HasPrefix(prefix) {
+		return []byte{}, members, nil
+	}
+	return next, members, nil
+}
+
+// sScanIntSet implements SScan for an IntSet-encoded set without
+// promoting it to ObjectEncodingHT. The cursor is the decimal string of
+// the last integer member examined; iteration resumes from the bitmap's
+// own sorted order rather than a TiKV range scan.
+func (set *Set) sScanIntSet(cursor []byte, match []byte, count int64) ([]byte, [][]byte, error) {
+	bm, err := set.loadIntSet()
+	if err != nil {
+		return nil, nil, err
+	}
+	it := bm.Iterator()
+	if len(cursor) > 0 {
+		if v, ok := parseSetMemberInt(cursor); ok {
+			it.AdvanceIfNeeded(v)
+			if it.HasNext() && it.PeekNext() == v {
+				it.Next()
+			}
+		}
+	}
+
+	var next []byte
+	members := make([][]byte, 0, count)
+	var examined int64
+	for it.HasNext() && examined < count {
+		member := []byte(strconv.FormatUint(uint64(it.Next()), 10))
+		matched, err := globMatch(match, member)
+		if err != nil {
+			return nil, nil, err
+		}
+		if matched {
+			members = append(members, member)
+		}
+		next = member
+		examined++
+	}
+	if !it.HasNext() {
+		return []byte{}, members, nil
+	}
+	return next, members, nil
+}
+
+// SUnionStore is like SUnion but stores the resulting set in destination.
+func (set *Set) SUnionStore(destination []byte, keys ...[]byte) (int64, error) {
+	members, err := set.SUnion(keys...)
+	if err != nil {
+		return 0, err
+	}
+	return set.storeResult(destination, members)
+}
+
+// SInterStore is like SInter but stores the resulting set in destination.
+func (set *Set) SInterStore(destination []byte, keys ...[]byte) (int64, error) {
+	members, err := set.SInter(keys...)
+	if err != nil {
+		return 0, err
+	}
+	return set.storeResult(destination, members)
+}
+
+// SDiffStore is like SDiff but stores the resulting set in destination.
+func (set *Set) SDiffStore(destination []byte, keys ...[]byte) (int64, error) {
+	members, err := set.SDiff(keys...)
+	if err != nil {
+		return 0, err
+	}
+	return set.storeResult(destination, members)
+}
@@ -0,0 +1,213 @@
+package db
+
+import (
+	"math"
+	"strconv"
+
+	"github.com/RoaringBitmap/roaring"
+)
+
+// ObjectEncodingIntSet marks a set whose members are all small
+// non-negative integers. Unlike ObjectEncodingHT, which stores one TiKV
+// key per member, an IntSet-encoded set stores its members as a single
+// serialised Roaring bitmap under the set's data key.
+const ObjectEncodingIntSet Encoding = ObjectEncodingHT + 1
+
+// DefaultSetMaxIntsetEntries mirrors Redis's set-max-intset-entries: once
+// an IntSet-encoded set would grow past this many members it is promoted
+// to the regular ObjectEncodingHT layout, one TiKV key per member.
+const DefaultSetMaxIntsetEntries = 512
+
+// MaxIntsetEntries is the live set-max-intset-entries threshold,
+// equivalent to Redis's config parameter of the same name. It defaults
+// to DefaultSetMaxIntsetEntries and is a package variable, not a
+// constant, so the server's config loader can override it once at
+// startup the same way it would any other tunable -- there is no
+// per-request config plumbing in this package to thread a value through
+// otherwise.
+var MaxIntsetEntries int64 = DefaultSetMaxIntsetEntries
+
+// maxIntsetEntries returns the promotion threshold for IntSet-encoded
+// sets.
+func (set *Set) maxIntsetEntries() int64 {
+	return MaxIntsetEntries
+}
+
+// parseSetMemberInt reports whether member is representable in the
+// Roaring bitmap backing an IntSet-encoded set: a base-10, non-negative
+// integer that fits in a uint32 AND round-trips back to the same bytes
+// formatted the same way (same check Redis's intset encoding does).
+// Without the round-trip check, non-canonical forms like "042", "+42"
+// and "-0" would parse to the same bitmap entry as "42" and "0", quietly
+// merging distinct set members and making every lookup of the
+// non-canonical member a false negative against the bitmap. Those forms,
+// and negative or out-of-range integers, are treated the same as
+// non-integer members and force promotion to ObjectEncodingHT.
+func parseSetMemberInt(member []byte) (uint32, bool) {
+	v, err := strconv.ParseInt(string(member), 10, 64)
+	if err != nil || v < 0 || v > math.MaxUint32 {
+		return 0, false
+	}
+	if strconv.FormatInt(v, 10) != string(member) {
+		return 0, false
+	}
+	return uint32(v), true
+}
+
+// loadIntSet decodes the Roaring bitmap stored under the set's data key.
+// A nonexistent set, or one that has never been written to, decodes to
+// an empty bitmap.
+func (set *Set) loadIntSet() (*roaring.Bitmap, error) {
+	bm := roaring.New()
+	if !set.Exists() {
+		return bm, nil
+	}
+	dkey := DataKey(set.txn.db, set.meta.ID)
+	value, err := set.txn.t.Get(dkey)
+	if err != nil {
+		if IsErrNotFound(err) {
+			return bm, nil
+		}
+		return nil, err
+	}
+	if err := bm.UnmarshalBinary(value); err != nil {
+		return nil, err
+	}
+	return bm, nil
+}
+
+// saveIntSet persists bm as the whole value of the set's data key. Unlike
+// ObjectEncodingHT, an IntSet-encoded set lives entirely in this one key.
+func (set *Set) saveIntSet(bm *roaring.Bitmap) error {
+	value, err := bm.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	dkey := DataKey(set.txn.db, set.meta.ID)
+	return set.txn.t.Set(dkey, value)
+}
+
+// bitmapMembers renders every integer in bm back into the same decimal
+// []byte form SMembers/SAdd use for ObjectEncodingHT members.
+func bitmapMembers(bm *roaring.Bitmap) [][]byte {
+	members := make([][]byte, 0, bm.GetCardinality())
+	it := bm.Iterator()
+	for it.HasNext() {
+		members = append(members, []byte(strconv.FormatUint(uint64(it.Next()), 10)))
+	}
+	return members
+}
+
+// allIntSet reports whether every existing set in sets is IntSet-encoded,
+// making the group eligible for the bitmap AND/OR/ANDNOT short-circuit in
+// SUnion/SInter/SDiff instead of a per-member merge.
+func allIntSet(sets []*Set) bool {
+	for _, s := range sets {
+		if s.Exists() && s.meta.Encoding != ObjectEncodingIntSet {
+			return false
+		}
+	}
+	return true
+}
+
+// sAddIntSet tries to add members to an IntSet-encoded set by folding
+// them into its Roaring bitmap. It returns ok=false, with the set already
+// promoted to ObjectEncodingHT, when a member can't be represented as a
+// bitmap entry or when set-max-intset-entries would be exceeded; the
+// caller is expected to retry through sAddHT in that case.
+func (set *Set) sAddIntSet(members [][]byte) (added int64, ok bool, err error) {
+	bm, err := set.loadIntSet()
+	if err != nil {
+		return 0, false, err
+	}
+	ms := RemoveRepByMap(members)
+	ints := make([]uint32, 0, len(ms))
+	for _, m := range ms {
+		v, isInt := parseSetMemberInt(m)
+		if !isInt {
+			return 0, false, set.promoteToHT(bm)
+		}
+		ints = append(ints, v)
+	}
+
+	newCardinality := bm.GetCardinality()
+	for _, v := range ints {
+		if !bm.Contains(v) {
+			newCardinality++
+		}
+	}
+	if int64(newCardinality) > set.maxIntsetEntries() {
+		return 0, false, set.promoteToHT(bm)
+	}
+
+	for _, v := range ints {
+		if !bm.Contains(v) {
+			added++
+			bm.Add(v)
+		}
+	}
+	if added == 0 {
+		return 0, true, nil
+	}
+	if err := set.saveIntSet(bm); err != nil {
+		return 0, false, err
+	}
+	set.meta.Len += added
+	if err := set.updateMeta(); err != nil {
+		return 0, false, err
+	}
+	return added, true, nil
+}
+
+// promoteToHT rewrites bm as one TiKV key per member and switches the
+// set's encoding to ObjectEncodingHT, so the regular per-member code
+// paths (sAddHT, SPop, SRem, SMove, SScan, SRandMember, ...) can take
+// over.
+func (set *Set) promoteToHT(bm *roaring.Bitmap) error {
+	dkey := DataKey(set.txn.db, set.meta.ID)
+	it := bm.Iterator()
+	for it.HasNext() {
+		member := []byte(strconv.FormatUint(uint64(it.Next()), 10))
+		ikey, err := setItemKey(dkey, member)
+		if err != nil {
+			return err
+		}
+		if err := set.txn.t.Set(ikey, SetNilValue); err != nil {
+			return err
+		}
+	}
+	if err := set.txn.t.Delete(dkey); err != nil && !IsErrNotFound(err) {
+		return err
+	}
+	set.meta.Encoding = ObjectEncodingHT
+	// Every key just written used setItemKey, so there's nothing for
+	// migrateLegacyItems to do afterwards.
+	set.meta.MigratedItemKeys = true
+	return set.updateMeta()
+}
+
+// ensureHT makes sure the set is both ObjectEncodingHT-encoded and using
+// the current length-prefixed item-key layout (see setItemKey in
+// set_keys.go), promoting from ObjectEncodingIntSet and/or migrating
+// legacy item keys as needed. Paths that only understand the per-member
+// key layout call this first so they can operate uniformly regardless
+// of how the set happened to be encoded or when it was last written.
+// This makes those paths require write access on their first call after
+// an upgrade even when the command itself is read-only (SMembers,
+// SIsmember, SScan, SRandMember): migrateLegacyItems persists
+// MigratedItemKeys once it has confirmed there's nothing left to
+// rewrite, so that confirmation itself doesn't need repeating, but the
+// confirming write still happens.
+func (set *Set) ensureHT() error {
+	if !set.Exists() {
+		return nil
+	}
+	if set.meta.Encoding == ObjectEncodingIntSet {
+		bm, err := set.loadIntSet()
+		if err != nil {
+			return err
+		}
+		return set.promoteToHT(bm)
+	}
+	return set.migrateLegacyItems()
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/RoaringBitmap/roaring"
+)
+
+// This file only covers the txn-free pieces of the IntSet encoding
+// (parsing, bitmap rendering, the allIntSet check). sAddIntSet,
+// promoteToHT, ensureHT and the *Set-through-*Transaction paths in
+// set.go (promotion on SAdd, SScan/SRandMember cursor and sampling
+// behaviour, SUnion/SInter/SDiff set algebra) still need coverage, but
+// that requires driving a real *Transaction against this package's
+// TiKV/mock test harness, and no such harness exists in this file tree.
+
+func TestParseSetMemberInt(t *testing.T) {
+	cases := []struct {
+		member string
+		want   uint32
+		wantOk bool
+	}{
+		{"0", 0, true},
+		{"42", 42, true},
+		{"4294967295", 4294967295, true},
+		{"-1", 0, false},
+		{"4294967296", 0, false},
+		{"3.14", 0, false},
+		{"abc", 0, false},
+		{"", 0, false},
+		// Non-canonical forms must be rejected, not silently normalised:
+		// accepting them would let distinct set members collapse onto
+		// the same bitmap entry.
+		{"007", 0, false},
+		{"+42", 0, false},
+		{"-0", 0, false},
+	}
+	for _, c := range cases {
+		got, ok := parseSetMemberInt([]byte(c.member))
+		if ok != c.wantOk {
+			t.Errorf("parseSetMemberInt(%q) ok = %v, want %v", c.member, ok, c.wantOk)
+			continue
+		}
+		if ok && got != c.want {
+			t.Errorf("parseSetMemberInt(%q) = %d, want %d", c.member, got, c.want)
+		}
+	}
+}
+
+func TestBitmapMembersRoundTrip(t *testing.T) {
+	bm := roaring.New()
+	bm.Add(3)
+	bm.Add(1)
+	bm.Add(2)
+
+	members := bitmapMembers(bm)
+	if len(members) != 3 {
+		t.Fatalf("bitmapMembers returned %d members, want 3", len(members))
+	}
+	seen := map[string]bool{}
+	for _, m := range members {
+		seen[string(m)] = true
+	}
+	for _, want := range []string{"1", "2", "3"} {
+		if !seen[want] {
+			t.Errorf("bitmapMembers missing member %q", want)
+		}
+	}
+}
+
+func TestAllIntSet(t *testing.T) {
+	intSet := &Set{exists: true, meta: &SetMeta{Object: Object{Encoding: ObjectEncodingIntSet}}}
+	htSet := &Set{exists: true, meta: &SetMeta{Object: Object{Encoding: ObjectEncodingHT}}}
+	missing := &Set{exists: false, meta: &SetMeta{Object: Object{Encoding: ObjectEncodingHT}}}
+
+	if !allIntSet([]*Set{intSet}) {
+		t.Error("allIntSet([intSet]) = false, want true")
+	}
+	if allIntSet([]*Set{intSet, htSet}) {
+		t.Error("allIntSet([intSet, htSet]) = true, want false")
+	}
+	// A nonexistent set doesn't constrain the encoding: there are no
+	// members to disagree with.
+	if !allIntSet([]*Set{intSet, missing}) {
+		t.Error("allIntSet([intSet, missing]) = false, want true")
+	}
+}
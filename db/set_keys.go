@@ -0,0 +1,117 @@
+package db
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+
+	"github.com/pingcap/tidb/kv"
+)
+
+const (
+	// setStartSep separates a set's base key from a member in an item
+	// key. setStopSep is the following byte, so [prefix+setStartSep,
+	// prefix+setStopSep) is a well-defined range covering every member
+	// regardless of what bytes the member itself contains.
+	setStartSep byte = ':'
+	setStopSep  byte = setStartSep + 1
+
+	// MaxKeySize bounds the base key passed into setItemKey.
+	MaxKeySize = 1<<16 - 1
+	// MaxSetMemberSize bounds a single set member.
+	MaxSetMemberSize = 1 << 20
+)
+
+// errSetMemberSize is returned instead of silently writing a member
+// larger than MaxSetMemberSize.
+var errSetMemberSize = errors.New("set member size exceeds MaxSetMemberSize")
+
+// setItemPrefix encodes key the way LedisDB/nodb's sEncodeSetKey does: a
+// big-endian uint16 length of key, then key itself, then setStartSep.
+// Every item key for key shares this as a literal prefix, and
+// [setItemPrefix(key), bytes with the last byte bumped to setStopSep)
+// is a complete, collision-free range for iterating them -- unlike
+// PrefixNext over key+':', it doesn't depend on members never
+// containing the separator byte.
+func setItemPrefix(key []byte) []byte {
+	buf := make([]byte, 2, 2+len(key)+1)
+	binary.BigEndian.PutUint16(buf, uint16(len(key)))
+	buf = append(buf, key...)
+	buf = append(buf, setStartSep)
+	return buf
+}
+
+// setItemRange returns the [start, stop) pair covering every item key
+// for key.
+func setItemRange(key []byte) (start, stop []byte) {
+	start = setItemPrefix(key)
+	stop = append(append([]byte{}, start[:len(start)-1]...), setStopSep)
+	return start, stop
+}
+
+// setItemKey builds the TiKV key for member in the set based at key.
+func setItemKey(key []byte, member []byte) ([]byte, error) {
+	if len(key) > MaxKeySize {
+		return nil, ErrInvalidLength
+	}
+	if len(member) > MaxSetMemberSize {
+		return nil, errSetMemberSize
+	}
+	return append(setItemPrefix(key), member...), nil
+}
+
+// migrateLegacyItems rewrites any item keys still using the pre-length-
+// prefix layout (key + ':' + member, reachable only via PrefixNext over
+// key+':') into the current length-prefixed layout, deleting the old
+// keys as it goes. It's called lazily off of ensureHT so existing sets
+// keep working without an offline migration. Once a set's
+// MigratedItemKeys meta flag is set, the scan is known to have nothing
+// left to do and is skipped entirely; otherwise it costs one range scan
+// (empty, in steady state) and then sets the flag so it isn't repeated.
+func (set *Set) migrateLegacyItems() error {
+	if set.meta.MigratedItemKeys {
+		return nil
+	}
+	dkey := DataKey(set.txn.db, set.meta.ID)
+	legacyPrefix := append(append([]byte{}, dkey...), setStartSep)
+	legacyEnd := kv.Key(legacyPrefix).PrefixNext()
+	newPrefix := setItemPrefix(dkey)
+
+	iter, err := set.txn.t.Iter(legacyPrefix, legacyEnd)
+	if err != nil {
+		return err
+	}
+	defer iter.Close()
+	for iter.Valid() && iter.Key().HasPrefix(legacyPrefix) {
+		key := append([]byte{}, []byte(iter.Key())...)
+		if bytes.HasPrefix(key, newPrefix) {
+			// Already in the current layout; a length-prefixed key can
+			// only land in the legacy range by chance, and re-writing it
+			// to itself would be a no-op anyway.
+			if err := iter.Next(); err != nil {
+				return err
+			}
+			continue
+		}
+		member := key[len(legacyPrefix):]
+		value, err := set.txn.t.Get(key)
+		if err != nil {
+			return err
+		}
+		newKey, err := setItemKey(dkey, member)
+		if err != nil {
+			return err
+		}
+		if err := set.txn.t.Set(newKey, value); err != nil {
+			return err
+		}
+		if err := set.txn.t.Delete(key); err != nil {
+			return err
+		}
+		if err := iter.Next(); err != nil {
+			return err
+		}
+	}
+	set.meta.MigratedItemKeys = true
+	return set.updateMeta()
+}
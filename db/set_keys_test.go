@@ -0,0 +1,71 @@
+package db
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSetItemKeyRange(t *testing.T) {
+	key := []byte("myset")
+	start, stop := setItemRange(key)
+
+	members := [][]byte{
+		[]byte("a"),
+		[]byte("z"),
+		// A member containing the separator byte (or bytes just past
+		// it) must still fall inside [start, stop) -- that's the whole
+		// point of length-prefixing the base key instead of relying on
+		// PrefixNext over key+':'.
+		[]byte(":colliding"),
+		[]byte(string(setStopSep) + "also-colliding"),
+		[]byte(""),
+	}
+	for _, m := range members {
+		ikey, err := setItemKey(key, m)
+		if err != nil {
+			t.Fatalf("setItemKey(%q, %q) returned error: %v", key, m, err)
+		}
+		if bytes.Compare(ikey, start) < 0 || bytes.Compare(ikey, stop) >= 0 {
+			t.Errorf("setItemKey(%q, %q) = %q, want within [%q, %q)", key, m, ikey, start, stop)
+		}
+		if !bytes.HasPrefix(ikey, setItemPrefix(key)) {
+			t.Errorf("setItemKey(%q, %q) = %q, want prefix %q", key, m, ikey, setItemPrefix(key))
+		}
+	}
+}
+
+func TestSetItemKeyDistinctBaseKeys(t *testing.T) {
+	// A base key that is itself a prefix of another base key must not
+	// let their item ranges overlap; the length prefix is what makes
+	// that true regardless of what separator-like bytes appear in
+	// either key.
+	start1, stop1 := setItemRange([]byte("foo"))
+	start2, stop2 := setItemRange([]byte("foobar"))
+	if bytes.Compare(stop1, start2) > 0 && bytes.Compare(stop2, start1) > 0 {
+		t.Errorf("ranges for %q and %q overlap: [%q,%q) vs [%q,%q)", "foo", "foobar", start1, stop1, start2, stop2)
+	}
+}
+
+func TestSetItemKeySizeLimits(t *testing.T) {
+	if _, err := setItemKey(bytes.Repeat([]byte("k"), MaxKeySize+1), []byte("m")); err != ErrInvalidLength {
+		t.Errorf("setItemKey with oversized base key returned %v, want ErrInvalidLength", err)
+	}
+	if _, err := setItemKey([]byte("k"), bytes.Repeat([]byte("m"), MaxSetMemberSize+1)); err != errSetMemberSize {
+		t.Errorf("setItemKey with oversized member returned %v, want errSetMemberSize", err)
+	}
+	if _, err := setItemKey(bytes.Repeat([]byte("k"), MaxKeySize), []byte("m")); err != nil {
+		t.Errorf("setItemKey at MaxKeySize returned unexpected error: %v", err)
+	}
+}
+
+func TestSetItemPrefixEncodesLength(t *testing.T) {
+	prefix := setItemPrefix([]byte("abc"))
+	want := []byte{0, 3, 'a', 'b', 'c', setStartSep}
+	if !bytes.Equal(prefix, want) {
+		t.Errorf("setItemPrefix(%q) = %v, want %v", "abc", prefix, want)
+	}
+	if !strings.HasSuffix(string(prefix), string(setStartSep)) {
+		t.Errorf("setItemPrefix(%q) = %q, want suffix %q", "abc", prefix, string(setStartSep))
+	}
+}